@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// arrangeGoLayout is --layout translated from strftime-style tokens to Go's
+// reference layout once at startup, in main().
+var arrangeGoLayout string
+
+var strftimeTokens = []struct {
+	token    string
+	goLayout string
+}{
+	{"%Y", "2006"},
+	{"%y", "06"},
+	{"%m", "01"},
+	{"%d", "02"},
+	{"%H", "15"},
+	{"%M", "04"},
+	{"%S", "05"},
+}
+
+// translateStrftimeLayout converts the handful of strftime tokens --layout
+// accepts into Go's reference-date layout syntax.
+func translateStrftimeLayout(layout string) string {
+	result := layout
+	for _, t := range strftimeTokens {
+		result = strings.ReplaceAll(result, t.token, t.goLayout)
+	}
+	return result
+}
+
+// arrangedEntry tracks one content hash's reserved destination path, and
+// whether that reservation has actually been fulfilled yet (see Reserve).
+type arrangedEntry struct {
+	destPath  string
+	committed bool
+}
+
+// arranger deduplicates source pictures by content hash and decides where
+// each one lands in the date-based --arrange tree.
+type arranger struct {
+	mu       sync.Mutex
+	seen     map[string]*arrangedEntry // content hash -> reserved/arranged entry
+	sequence map[string]int            // date-formatted prefix -> next free sequence number
+}
+
+// newArranger seeds its dedup state from destination's existing content/
+// tree (if any), so re-running the tool against the same destination
+// recognizes pictures arranged by a previous run instead of re-importing
+// them under a new sequence number.
+func newArranger(destination string) (*arranger, error) {
+	a := &arranger{
+		seen:     make(map[string]*arrangedEntry),
+		sequence: make(map[string]int),
+	}
+
+	contentDir := path.Join(destination, "content")
+	shards, err := os.ReadDir(contentDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return a, nil
+		}
+		return nil, err
+	}
+
+	for _, shard := range shards {
+		if !shard.IsDir() {
+			continue
+		}
+		shardPath := path.Join(contentDir, shard.Name())
+		files, err := os.ReadDir(shardPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			hash := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+			a.seen[hash] = &arrangedEntry{destPath: path.Join(shardPath, file.Name()), committed: true}
+		}
+	}
+
+	return a, nil
+}
+
+// Reserve hashes the source picture and reserves a spot for it under
+// destination, named after date using arrangeGoLayout. ok is false when an
+// identical file (by content hash) has already been successfully arranged,
+// in which case the caller should skip the write entirely.
+//
+// Reserving a destPath does not mark hash as arranged: a reservation only
+// becomes visible to other callers as a completed duplicate once Commit is
+// called for it. This keeps a failed annotate-or-copy from poisoning the
+// dedup state, which would otherwise make every later copy of that same
+// picture look like a duplicate of a write that never actually happened.
+func (a *arranger) Reserve(rootDirectory string, f os.FileInfo, destination string, date time.Time) (destPath string, hash string, ok bool, err error) {
+	hash, err = hashFile(path.Join(rootDirectory, f.Name()))
+	if err != nil {
+		return "", "", false, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if entry, exists := a.seen[hash]; exists {
+		if entry.committed {
+			return entry.destPath, hash, false, nil
+		}
+		// A previous attempt for this hash reserved a path but never
+		// committed (still in flight, or it failed). Reuse that path so a
+		// retry lands where the first attempt would have, instead of
+		// burning another sequence number.
+		return entry.destPath, hash, true, nil
+	}
+
+	prefix := date.Format(arrangeGoLayout)
+	seq := a.sequence[prefix]
+	a.sequence[prefix] = seq + 1
+
+	destPath = path.Join(destination, fmt.Sprintf("%s-%d%s", prefix, seq, filepath.Ext(f.Name())))
+	if err := os.MkdirAll(path.Dir(destPath), os.ModePerm); err != nil {
+		return "", "", false, err
+	}
+
+	a.seen[hash] = &arrangedEntry{destPath: destPath}
+	return destPath, hash, true, nil
+}
+
+// Commit marks hash's reservation as fulfilled, so subsequent Reserve calls
+// for the same content report it as an arranged duplicate. Call it only
+// after the annotate-and-copy for that hash has actually succeeded.
+func (a *arranger) Commit(hash string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if entry, exists := a.seen[hash]; exists {
+		entry.committed = true
+	}
+}
+
+// createContentShards pre-creates the 256 content/<first-byte-hex>/
+// subdirectories that dropContentCopy writes into.
+func createContentShards(destination string) error {
+	for i := 0; i < 256; i++ {
+		shard := hex.EncodeToString([]byte{byte(i)})
+		if err := os.MkdirAll(path.Join(destination, "content", shard), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropContentCopy writes an untouched copy of the source picture into
+// destination/content/<first-byte-hex>/<hash><ext>, keyed by hash (the
+// already-computed hash of that exact source file). Storing the source
+// rather than the annotated output keeps the content tree self-verifying:
+// re-hashing a stored file always reproduces the name it's stored under.
+func dropContentCopy(rootDirectory string, f os.FileInfo, destination string, hash string) error {
+	contentPath := path.Join(destination, "content", hash[:2], hash+filepath.Ext(f.Name()))
+
+	src, err := os.Open(path.Join(rootDirectory, f.Name()))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(contentPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// hashFile streams filePath through md5 without loading it fully in memory.
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
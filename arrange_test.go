@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSourceFile creates name under dir with the given content and returns
+// a fakeFileInfo for it, as scanFiles would hand processFile.
+func writeSourceFile(t *testing.T, dir, name, content string) fakeFileInfo {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("writeSourceFile(%q): %v", name, err)
+	}
+	return fakeFileInfo{name: name}
+}
+
+func TestArrangerReserveThenDuplicate(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	f := writeSourceFile(t, src, "a.jpg", "same content")
+
+	a, err := newArranger(dest)
+	if err != nil {
+		t.Fatalf("newArranger: %v", err)
+	}
+
+	destPath, hash, ok, err := a.Reserve(src, f, dest, time.Now())
+	if err != nil || !ok {
+		t.Fatalf("first Reserve: destPath=%q ok=%v err=%v, want ok=true err=nil", destPath, ok, err)
+	}
+	a.Commit(hash)
+
+	g := writeSourceFile(t, src, "b.jpg", "same content")
+	again, _, ok, err := a.Reserve(src, g, dest, time.Now())
+	if err != nil {
+		t.Fatalf("second Reserve: %v", err)
+	}
+	if ok {
+		t.Fatalf("second Reserve of identical content should report a duplicate, got ok=true")
+	}
+	if again != destPath {
+		t.Errorf("second Reserve destPath = %q, want the first reservation's %q", again, destPath)
+	}
+}
+
+func TestArrangerReserveRetryAfterFailure(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	f := writeSourceFile(t, src, "a.jpg", "will fail to annotate")
+
+	a, err := newArranger(dest)
+	if err != nil {
+		t.Fatalf("newArranger: %v", err)
+	}
+
+	first, _, ok, err := a.Reserve(src, f, dest, time.Now())
+	if err != nil || !ok {
+		t.Fatalf("first Reserve: destPath=%q ok=%v err=%v, want ok=true err=nil", first, ok, err)
+	}
+	// Simulate the caller's annotate/copy failing: no Commit follows.
+
+	second, _, ok, err := a.Reserve(src, f, dest, time.Now())
+	if err != nil {
+		t.Fatalf("retry Reserve: %v", err)
+	}
+	if !ok {
+		t.Fatalf("retry Reserve after an uncommitted reservation should not be treated as a duplicate")
+	}
+	if second != first {
+		t.Errorf("retry Reserve destPath = %q, want the original reservation's %q", second, first)
+	}
+}
+
+func TestNewArrangerReseedsFromExistingContentTree(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	f := writeSourceFile(t, src, "a.jpg", "already arranged by a previous run")
+
+	hash, err := hashFile(filepath.Join(src, "a.jpg"))
+	if err != nil {
+		t.Fatalf("hashFile: %v", err)
+	}
+	shardDir := filepath.Join(dest, "content", hash[:2])
+	if err := os.MkdirAll(shardDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(shardDir, hash+".jpg"), []byte("already arranged by a previous run"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	a, err := newArranger(dest)
+	if err != nil {
+		t.Fatalf("newArranger: %v", err)
+	}
+
+	_, _, ok, err := a.Reserve(src, f, dest, time.Now())
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if ok {
+		t.Errorf("Reserve of a file already present in a prior run's content tree should report a duplicate")
+	}
+}
+
+func TestTranslateStrftimeLayout(t *testing.T) {
+	cases := []struct {
+		layout string
+		want   string
+	}{
+		{"%Y/%m/%d-%H%M%S", "2006/01/02-150405"},
+		{"%y-%m-%d", "06-01-02"},
+		{"no tokens here", "no tokens here"},
+	}
+
+	for _, c := range cases {
+		if got := translateStrftimeLayout(c.layout); got != c.want {
+			t.Errorf("translateStrftimeLayout(%q) = %q, want %q", c.layout, got, c.want)
+		}
+	}
+}
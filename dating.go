@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// defaultFilenameLayout is the historical pola-camera export pattern, kept as
+// the first layout tried by the filename date source.
+const defaultFilenameLayout = "2006-01-02_15-04-05-pola.jpg"
+
+// stringListFlag collects repeated occurrences of a flag into a slice.
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+var filenameLayouts = stringListFlag{defaultFilenameLayout}
+
+// resolvePictureDate walks the --date-source chain in order and returns the
+// first date a source manages to produce for f.
+func resolvePictureDate(rootDirectory string, f os.FileInfo) (time.Time, error) {
+	var lastErr error
+	for _, source := range strings.Split(*dateSource, ",") {
+		switch strings.TrimSpace(source) {
+		case "exif":
+			if t, err := dateFromExif(rootDirectory, f); err == nil {
+				return t, nil
+			} else {
+				lastErr = err
+			}
+		case "filename":
+			if t, err := dateFromFilename(f); err == nil {
+				return t, nil
+			} else {
+				lastErr = err
+			}
+		case "mtime":
+			if t, err := dateFromMtime(rootDirectory, f); err == nil {
+				return t, nil
+			} else {
+				lastErr = err
+			}
+		default:
+			lastErr = fmt.Errorf("unknown date source %q", source)
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("--date-source is empty, no date could be resolved")
+	}
+	return time.Time{}, lastErr
+}
+
+// dateFromExif reads the capture date straight out of the JPEG's EXIF tags,
+// in-process, replacing the former shell-out to imagemagick's identify.
+func dateFromExif(rootDirectory string, f os.FileInfo) (time.Time, error) {
+	file, err := os.Open(path.Join(rootDirectory, f.Name()))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+
+	x, err := exif.Decode(file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return x.DateTime()
+}
+
+// dateFromFilename tries every configured filename layout in order, starting
+// with the built-in pola pattern.
+func dateFromFilename(f os.FileInfo) (time.Time, error) {
+	var lastErr error
+	for _, layout := range filenameLayouts {
+		if t, err := time.Parse(layout, f.Name()); err == nil {
+			return t, nil
+		} else {
+			lastErr = err
+		}
+	}
+	return time.Time{}, lastErr
+}
+
+// dateFromMtime falls back to the file's last modification time.
+func dateFromMtime(rootDirectory string, f os.FileInfo) (time.Time, error) {
+	info, err := os.Stat(path.Join(rootDirectory, f.Name()))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
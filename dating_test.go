@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+type fakeFileInfo struct{ name string }
+
+func (f fakeFileInfo) Name() string       { return f.name }
+func (f fakeFileInfo) Size() int64        { return 0 }
+func (f fakeFileInfo) Mode() os.FileMode  { return 0 }
+func (f fakeFileInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeFileInfo) IsDir() bool        { return false }
+func (f fakeFileInfo) Sys() interface{}   { return nil }
+
+func TestDateFromFilename(t *testing.T) {
+	original := filenameLayouts
+	defer func() { filenameLayouts = original }()
+	filenameLayouts = stringListFlag{defaultFilenameLayout, "2006-01-02"}
+
+	cases := []struct {
+		name    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2024-03-05_10-20-30-pola.jpg", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC), false},
+		{"2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), false},
+		{"not-a-date.jpg", time.Time{}, true},
+	}
+
+	for _, c := range cases {
+		got, err := dateFromFilename(fakeFileInfo{name: c.name})
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("dateFromFilename(%q): expected error, got none", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("dateFromFilename(%q): unexpected error: %v", c.name, err)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("dateFromFilename(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Locale controls how a date is rendered to end users: the language used
+// for month names, and the ordinal notation for the day of the month (e.g.
+// "1er janvier" in French, "1st January" in English, "3. Januar" in German).
+type Locale interface {
+	MonthName(month time.Month) string
+	FormatDay(t time.Time, layout string) string
+}
+
+// currentLocale is resolved once from --locale/--locale-file in main().
+var currentLocale Locale
+
+// builtinLocales are selectable by name via --locale.
+var builtinLocales = map[string]Locale{
+	"fr": builtinLocale{months: frMonths, ordinal: firstDayOnlyOrdinal("er")},
+	"en": builtinLocale{months: enMonths, ordinal: englishOrdinal},
+	"de": builtinLocale{months: deMonths, ordinal: func(day int) string { return fmt.Sprintf("%02d.", day) }},
+	"es": builtinLocale{months: esMonths, ordinal: firstDayOnlyOrdinal("º")},
+	"it": builtinLocale{months: itMonths, ordinal: firstDayOnlyOrdinal("º")},
+}
+
+var frMonths = map[time.Month]string{
+	time.January:   "janvier",
+	time.February:  "février",
+	time.March:     "mars",
+	time.April:     "avril",
+	time.May:       "mai",
+	time.June:      "juin",
+	time.July:      "juillet",
+	time.August:    "août",
+	time.September: "septembre",
+	time.October:   "octobre",
+	time.November:  "novembre",
+	time.December:  "décembre",
+}
+
+var enMonths = map[time.Month]string{
+	time.January:   "January",
+	time.February:  "February",
+	time.March:     "March",
+	time.April:     "April",
+	time.May:       "May",
+	time.June:      "June",
+	time.July:      "July",
+	time.August:    "August",
+	time.September: "September",
+	time.October:   "October",
+	time.November:  "November",
+	time.December:  "December",
+}
+
+var deMonths = map[time.Month]string{
+	time.January:   "Januar",
+	time.February:  "Februar",
+	time.March:     "März",
+	time.April:     "April",
+	time.May:       "Mai",
+	time.June:      "Juni",
+	time.July:      "Juli",
+	time.August:    "August",
+	time.September: "September",
+	time.October:   "Oktober",
+	time.November:  "November",
+	time.December:  "Dezember",
+}
+
+var esMonths = map[time.Month]string{
+	time.January:   "enero",
+	time.February:  "febrero",
+	time.March:     "marzo",
+	time.April:     "abril",
+	time.May:       "mayo",
+	time.June:      "junio",
+	time.July:      "julio",
+	time.August:    "agosto",
+	time.September: "septiembre",
+	time.October:   "octubre",
+	time.November:  "noviembre",
+	time.December:  "diciembre",
+}
+
+var itMonths = map[time.Month]string{
+	time.January:   "gennaio",
+	time.February:  "febbraio",
+	time.March:     "marzo",
+	time.April:     "aprile",
+	time.May:       "maggio",
+	time.June:      "giugno",
+	time.July:      "luglio",
+	time.August:    "agosto",
+	time.September: "settembre",
+	time.October:   "ottobre",
+	time.November:  "novembre",
+	time.December:  "dicembre",
+}
+
+// firstDayOnlyOrdinal builds an ordinal func that only marks the 1st of the
+// month (e.g. "1er", "1º") and renders every other day as a zero-padded
+// number, matching how French, Spanish and Italian dates are conventionally
+// written (and the zero-padded "02" layout token it replaces).
+func firstDayOnlyOrdinal(firstDaySuffix string) func(int) string {
+	return func(day int) string {
+		if day == 1 {
+			return "1" + firstDaySuffix
+		}
+		return fmt.Sprintf("%02d", day)
+	}
+}
+
+// englishOrdinal renders every day with its English ordinal suffix: 1st,
+// 2nd, 3rd, 4th, ... 21st, 22nd, 23rd, 24th, ...
+func englishOrdinal(day int) string {
+	suffix := "th"
+	if day%100 < 11 || day%100 > 13 {
+		switch day % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", day, suffix)
+}
+
+// builtinLocale is the Locale implementation shared by every shipped
+// language: only the month names and ordinal rule differ.
+type builtinLocale struct {
+	months  map[time.Month]string
+	ordinal func(int) string
+}
+
+func (l builtinLocale) MonthName(month time.Month) string {
+	if name, ok := l.months[month]; ok {
+		return name
+	}
+	return month.String()
+}
+
+func (l builtinLocale) FormatDay(t time.Time, layout string) string {
+	return applyMonthAndOrdinal(t, layout, l.MonthName, l.ordinal)
+}
+
+// dayTokens are the Go reference-layout day-of-month directives we know how
+// to locate and replace, tried in order of how unambiguously they can be
+// found inside layout (see findDayToken).
+var dayTokens = []string{"02", "_2"}
+
+// applyMonthAndOrdinal takes the result of t.Format(layout) and substitutes
+// the English month name and zero-padded day it contains for their
+// localized equivalents, so any locale can be built from just a month table
+// and an ordinal rule.
+//
+// The day substitution is positional: it locates the day token (e.g. "02")
+// inside layout itself, then computes the matching byte range in formatted
+// by re-rendering the prefix up to that token. Searching for the day's own
+// rendered value inside formatted instead (the previous approach) would
+// also match that value if it happened to appear elsewhere, e.g. the "01"
+// day-of-month from a "2006-01-02" layout colliding with the "01" month.
+func applyMonthAndOrdinal(t time.Time, layout string, monthName func(time.Month) string, ordinal func(int) string) string {
+	formatted := t.Format(layout)
+
+	if monthKey := t.Format("January"); strings.Contains(formatted, monthKey) {
+		formatted = strings.Replace(formatted, monthKey, monthName(t.Month()), 1)
+	}
+
+	if token, idx := findDayToken(layout); idx >= 0 {
+		start := len(t.Format(layout[:idx]))
+		end := start + len(t.Format(token))
+		if end <= len(formatted) {
+			formatted = formatted[:start] + ordinal(t.Day()) + formatted[end:]
+		}
+	}
+
+	return formatted
+}
+
+// findDayToken returns the first dayTokens entry present in layout and its
+// byte offset, or ("", -1) if layout names the day some other way (e.g. the
+// un-padded "2", which we skip: its single digit is indistinguishable from
+// the leading digit of "2006" without a full layout tokenizer).
+func findDayToken(layout string) (string, int) {
+	for _, token := range dayTokens {
+		if idx := strings.Index(layout, token); idx >= 0 {
+			return token, idx
+		}
+	}
+	return "", -1
+}
+
+// resolveLocale picks the Locale to use: a --locale-file always wins over
+// --locale, and an empty/unknown --locale falls back to an error rather
+// than silently defaulting, since a wrong locale would otherwise only show
+// up as mojibake in the exported pictures.
+func resolveLocale(name string, file string) (Locale, error) {
+	if file != "" {
+		return loadLocaleFile(file)
+	}
+	if l, ok := builtinLocales[name]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("unknown locale %q (built-in: fr, en, de, es, it)", name)
+}
+
+// localeFileContent is the schema accepted by --locale-file, as either YAML
+// or JSON depending on the file extension.
+type localeFileContent struct {
+	Months         map[string]string `json:"months" yaml:"months"`
+	Ordinals       map[string]string `json:"ordinals" yaml:"ordinals"`
+	DefaultOrdinal string            `json:"defaultOrdinal" yaml:"defaultOrdinal"`
+}
+
+// fileLocale is a Locale backed by a user-supplied locale file, so people
+// can support a language we don't ship without recompiling.
+type fileLocale struct {
+	content localeFileContent
+}
+
+func (l fileLocale) MonthName(month time.Month) string {
+	if name, ok := l.content.Months[month.String()]; ok {
+		return name
+	}
+	return month.String()
+}
+
+func (l fileLocale) ordinal(day int) string {
+	if custom, ok := l.content.Ordinals[strconv.Itoa(day)]; ok {
+		return custom
+	}
+	if l.content.DefaultOrdinal != "" {
+		return fmt.Sprintf(l.content.DefaultOrdinal, day)
+	}
+	return strconv.Itoa(day)
+}
+
+func (l fileLocale) FormatDay(t time.Time, layout string) string {
+	return applyMonthAndOrdinal(t, layout, l.MonthName, l.ordinal)
+}
+
+func loadLocaleFile(path string) (Locale, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read locale file %v: %w", path, err)
+	}
+
+	var content localeFileContent
+	switch ext := filepath.Ext(path); ext {
+	case ".json":
+		err = json.Unmarshal(raw, &content)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &content)
+	default:
+		return nil, fmt.Errorf("unsupported locale file extension %q (expected .json, .yaml or .yml)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't parse locale file %v: %w", path, err)
+	}
+
+	return fileLocale{content: content}, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnglishOrdinal(t *testing.T) {
+	cases := map[int]string{
+		1:  "1st",
+		2:  "2nd",
+		3:  "3rd",
+		4:  "4th",
+		11: "11th",
+		12: "12th",
+		13: "13th",
+		21: "21st",
+		22: "22nd",
+		23: "23rd",
+		24: "24th",
+	}
+	for day, want := range cases {
+		if got := englishOrdinal(day); got != want {
+			t.Errorf("englishOrdinal(%d) = %q, want %q", day, got, want)
+		}
+	}
+}
+
+func TestFirstDayOnlyOrdinal(t *testing.T) {
+	ordinal := firstDayOnlyOrdinal("er")
+
+	if got := ordinal(1); got != "1er" {
+		t.Errorf("ordinal(1) = %q, want %q", got, "1er")
+	}
+	if got := ordinal(5); got != "05" {
+		t.Errorf("ordinal(5) = %q, want %q", got, "05")
+	}
+	if got := ordinal(21); got != "21" {
+		t.Errorf("ordinal(21) = %q, want %q", got, "21")
+	}
+}
+
+// TestApplyMonthAndOrdinalDayYearCollision guards against a regression where
+// a value-based search for the rendered day replaced the wrong occurrence:
+// March 1, 2001 formatted with "2006-01-02" renders the day as "01", which
+// is also the month's rendered value earlier in the string.
+func TestApplyMonthAndOrdinalDayYearCollision(t *testing.T) {
+	date := time.Date(2001, time.March, 1, 0, 0, 0, 0, time.UTC)
+	ordinal := firstDayOnlyOrdinal("er")
+
+	got := applyMonthAndOrdinal(date, "2006-01-02", builtinLocales["fr"].MonthName, ordinal)
+	want := "2001-03-1er"
+	if got != want {
+		t.Errorf("applyMonthAndOrdinal(...) = %q, want %q", got, want)
+	}
+}
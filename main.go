@@ -3,15 +3,10 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
-	"strconv"
-	"strings"
-	"sync"
 	"time"
 
 	"golang.org/x/text/unicode/norm"
@@ -30,33 +25,22 @@ var (
 	useGoroutine  = flag.Bool("use-goroutine", false, "Are goroutine used?")
 	maxGoroutines = flag.Int("max-goroutines", 10, "Maximum number of goroutines to run in parallel, equivalent to maximum images to resize in parallel")
 	help          = flag.Bool("help", false, "Display default flag")
-	guard         chan struct{}
+	dateSource    = flag.String("date-source", "exif,filename,mtime", "Ordered, comma-separated list of date sources to try for each picture (exif, filename, mtime)")
+	arrange       = flag.Bool("arrange", false, "Arrange annotated pictures into a date-based dest/<layout> tree, deduplicated by content hash, with a content-addressed copy alongside")
+	layout        = flag.String("layout", "%Y/%m/%d-%H%M%S", "strftime-style layout (%Y %y %m %d %H %M %S) used to name the --arrange output tree")
+	recursive     = flag.Bool("recursive", true, "Recursively scan subdirectories of --src")
+	locale        = flag.String("locale", "fr", "Locale used for month names and ordinal-day formatting (fr, en, de, es, it)")
+	localeFile    = flag.String("locale-file", "", "Path to a YAML/JSON file overriding the built-in locale's month names and ordinal rules")
 )
 
-var formats = map[string]string{
-	"January":   "janvier",
-	"February":  "février",
-	"March":     "mars",
-	"April":     "avril",
-	"May":       "mai",
-	"June":      "juin",
-	"July":      "juillet",
-	"August":    "août",
-	"September": "septembre",
-	"October":   "octobre",
-	"November":  "novembre",
-	"December":  "décembre",
+func init() {
+	flag.Var(&filenameLayouts, "filename-layout", "Additional time.Parse layout to try against the filename when using the filename date source (may be repeated, tried in order after the built-in pola layout)")
 }
 
 func main() {
 	// First retrieve CLI flags values to check if help is necessary, just in case
 	flag.Parse()
 
-	// In case of a parallel run, let's initialize a guard to avoid running too much concurrent goroutines
-	if *useGoroutine {
-		guard = make(chan struct{}, *maxGoroutines)
-	}
-
 	// Ok, for now help is just defaults
 	// TODO : add app presentation & a little bit more description, just because you  can
 	if *help {
@@ -64,12 +48,11 @@ func main() {
 		os.Exit(0)
 	}
 
-	// We expect the user to use completion, but, as an engineer, you should never trust user inputs!
-	// Go is a try & ask for forgiveness language, don't check first that the directory exists
-	files, err := ioutil.ReadDir(*src)
+	resolvedLocale, err := resolveLocale(*locale, *localeFile)
 	if err != nil {
-		logger.Fatalln(err)
+		logger.Fatalln("Can't resolve locale : ", err)
 	}
+	currentLocale = resolvedLocale
 
 	// For more convenience, we will create the destination folder for the user if it doesn't exists
 	destFolderPath := path.Join(*src, *dest)
@@ -80,117 +63,105 @@ func main() {
 		}
 	}
 
-	var wg sync.WaitGroup
-	actOnFiles(&wg, files, *loc, *src, destFolderPath)
-	wg.Wait()
-}
-
-func actOnFiles(wg *sync.WaitGroup, files []os.FileInfo, location string, source string, destination string) {
-	// Now, we'll iterate through all files inside a dedicated folder
-	// TODO : add a flag to allow a recursive scan of directories, because we all should be lazy, and it's cheap
-	logger.Printf("%v files to process on directory %v", len(files), source)
-	for _, file := range files {
-		if *useGoroutine {
-			guard <- struct{}{} // would block if guard channel is already filled
-
-			wg.Add(1)
-
-			go func(sy *sync.WaitGroup, f os.FileInfo, loca string, sour string, des string) {
-				defer sy.Done()
-
-				actOnFile(wg, f, loca, sour, des)
-			}(wg, file, location, source, destination)
-		} else {
-			actOnFile(wg, file, location, source, destination)
+	var fileArranger *arranger
+	if *arrange {
+		arrangeGoLayout = translateStrftimeLayout(*layout)
+		if err := createContentShards(destFolderPath); err != nil {
+			logger.Fatalln("An error occured when trying to create the content-addressed tree : ", err)
+		}
+		fileArranger, err = newArranger(destFolderPath)
+		if err != nil {
+			logger.Fatalln("An error occured when seeding the arranger from an existing content tree : ", err)
 		}
 	}
-}
 
-func actOnFile(wg *sync.WaitGroup, f os.FileInfo, location string, source string, destination string) {
-	if *useGoroutine {
-		defer func() {
-			<-guard
-		}()
+	workers := 1
+	if *useGoroutine && *maxGoroutines > 1 {
+		workers = *maxGoroutines
 	}
 
-	if f.IsDir() {
-		if f.Name() != path.Base(destination) {
-			newSource := path.Join(source, f.Name())
-			// logger.Println("New  source is : ", newSource, "Source is : ", source)
-			files, err := ioutil.ReadDir(newSource)
-			if err != nil {
-				logger.Println("Can't read directory :", newSource)
-			} else {
-				actOnFiles(wg, files, f.Name(), newSource, destination)
-			}
-		}
-		return
-	}
+	summary := &scanSummary{}
+	jobs := scanFiles(*src, destFolderPath, *recursive, *loc)
+	runWorkerPool(jobs, workers, destFolderPath, fileArranger, summary)
+
+	logger.Println("Done : ", summary)
+}
+
+// processFile runs the parse -> annotate (-> arrange) pipeline for a single
+// file discovered by scanFiles, recording its outcome into summary.
+func processFile(job fileJob, destination string, a *arranger, summary *scanSummary) {
+	f := job.info
 
 	// User can enter extension like he want, we just want to include what has been entered, sometimes the simpler the better
-	if localExt := filepath.Ext(f.Name()); localExt == "" || !strings.Contains(*ext, localExt) {
-		logger.Printf("%v excluded, invalid extension [%v] (expect contained in %v)", f.Name(), localExt, *ext)
+	if !hasAllowedExt(f.Name()) {
+		logger.Printf("%v excluded, invalid extension [%v] (expect contained in %v)", f.Name(), filepath.Ext(f.Name()), *ext)
+		summary.record("skipped-wrong-ext")
 		return
 	}
 
 	// Yeah, a little bit of context, you know, because we can
 	logger.Println("Processing image : ", f.Name())
 
-	// // Get raw date as string from exif data with identify from imagemagick
-	// exifPictureDate := extractExifInfoFrom(pattern, f)
-
-	// // Convert string to date
-	// pictureDate, err := time.Parse("2006:01:02 15:04:05", exifPictureDate)
-
-	// Date is retrieved from file-name
-	// TODO : add a flag to specify which kind of parsing should be used (consider several can be chained, and in which order)
-	pictureDate, err := time.Parse("2006-01-02_15-04-05-pola.jpg", f.Name())
+	// Date is retrieved from the --date-source chain (exif, filename, mtime, ...)
+	pictureDate, err := resolvePictureDate(job.root, f)
 	if err != nil {
 		logger.Println("Invalid date for file : ", f.Name(), err)
+		summary.record("skipped-no-date")
 		return
 	}
 
 	// Convert date to localized date with only relevant data,  like omitting hour minutes & seconds
-	var displayedDate string
-	if pictureDate.Day() == 1 {
-		displayedDate = fmt.Sprintf("1er %v", localizeDate(pictureDate, "January 2006"))
-	} else {
-		displayedDate = localizeDate(pictureDate, "02 January 2006")
-	}
+	displayedDate := localizeDate(pictureDate, "02 January 2006")
 
-	// Inject localized date into image with imagemagick
+	// Inject localized date into image
 	var annotation string
-	if location != "" {
-		annotation = fmt.Sprintf(*format, location, displayedDate)
+	if job.location != "" {
+		annotation = fmt.Sprintf(*format, job.location, displayedDate)
 	} else {
 		annotation = displayedDate
 	}
-	annotateImageWith(source, f, destination, annotation, *textSize, *bottomMargin)
-}
 
-func localizeDate(date time.Time, layout string) string {
-	monthKey := date.Format("January")
-	return strings.Replace(date.Format(layout), monthKey, formats[monthKey], -1)
-}
+	destPath := path.Join(destination, f.Name())
+	if *arrange {
+		arranged, hash, ok, err := a.Reserve(job.root, f, destination, pictureDate)
+		if err != nil {
+			logger.Println("Can't arrange file : ", f.Name(), err)
+			summary.record("failed-annotate")
+			return
+		}
+		if !ok {
+			logger.Println("Duplicate picture skipped, already arranged as : ", arranged)
+			return
+		}
+		destPath = arranged
 
-func extractExifInfoFrom(rootDirectory string, image os.FileInfo) string {
-	out, err := exec.Command("identify", "-format", "%[EXIF:DateTimeOriginal]", path.Join(rootDirectory, image.Name())).CombinedOutput()
-	if err != nil {
-		logger.Println("Call to identify return following error : ", err, out)
+		if err := annotateImageWith(job.root, f, destPath, annotation, *textSize, *bottomMargin); err != nil {
+			logger.Println("Can't annotate : ", f.Name(), err)
+			summary.record("failed-annotate")
+			return
+		}
+		if err := dropContentCopy(job.root, f, destination, hash); err != nil {
+			logger.Println("Can't write content-addressed copy for : ", f.Name(), err)
+			summary.record("failed-annotate")
+			return
+		}
+		a.Commit(hash)
+		summary.record("processed")
+		return
 	}
-	return string(out)
-}
 
-func annotateImageWith(rootDirectory string, image os.FileInfo, destination string, annotation string, textInPointSize int, bottomMargin int) {
-	bottomHeightInPixel := 350
-	textInPixel := (float32(textInPointSize) / 0.75) / 2
-	textPositionFromBottom := (float32(bottomHeightInPixel) / 2) - (textInPixel / 2) - float32(bottomMargin)
-	annotateFormat := fmt.Sprintf("+0+%d", int32(textPositionFromBottom))
-	cmd := exec.Command("convert", path.Join(rootDirectory, image.Name()), "-font", *font, "-pointsize", strconv.Itoa(textInPointSize), "-fill", "black", "-gravity", "south", "-annotate", annotateFormat, normalizeUtf8Style(annotation), path.Join(destination, image.Name()))
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.Println("Call to convert return following error :", err, string(out))
+	if err := annotateImageWith(job.root, f, destPath, annotation, *textSize, *bottomMargin); err != nil {
+		logger.Println("Can't annotate : ", f.Name(), err)
+		summary.record("failed-annotate")
+		return
 	}
+	summary.record("processed")
+}
+
+// localizeDate dispatches to the configured --locale/--locale-file for
+// month names and ordinal-day formatting.
+func localizeDate(date time.Time, layout string) string {
+	return currentLocale.FormatDay(date, layout)
 }
 
 // https://blog.golang.org/normalization
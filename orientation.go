@@ -0,0 +1,82 @@
+package main
+
+import "image"
+
+// applyOrientation rotates/flips img according to an EXIF Orientation tag
+// value (1-8) so the result always displays upright, regardless of how the
+// camera recorded it.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return flipHorizontal(img)
+	case 3:
+		return rotate180(img)
+	case 4:
+		return flipVertical(img)
+	case 5:
+		return rotate90(flipHorizontal(img))
+	case 6:
+		return rotate90(img)
+	case 7:
+		return rotate270(flipHorizontal(img))
+	case 8:
+		return rotate270(img)
+	default:
+		return img
+	}
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(x, y, img.At(x, y))
+		}
+	}
+	return dst
+}
+
+func flipHorizontal(img image.Image) image.Image {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(b.Max.X-1-(x-b.Min.X), y, src.At(x, y))
+		}
+	}
+	return dst
+}
+
+func flipVertical(img image.Image) image.Image {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(x, b.Max.Y-1-(y-b.Min.Y), src.At(x, y))
+		}
+	}
+	return dst
+}
+
+func rotate180(img image.Image) image.Image {
+	return flipVertical(flipHorizontal(img))
+}
+
+func rotate90(img image.Image) image.Image {
+	src := toNRGBA(img)
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(b.Max.Y-1-(y-b.Min.Y), x-b.Min.X, src.At(x, y))
+		}
+	}
+	return dst
+}
+
+func rotate270(img image.Image) image.Image {
+	return rotate180(rotate90(img))
+}
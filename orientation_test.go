@@ -0,0 +1,68 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// newStripImage builds a 2x1 test image: (0,0) red, (1,0) green.
+func newStripImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(1, 0, color.NRGBA{G: 255, A: 255})
+	return img
+}
+
+func colorsEqual(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
+
+func TestApplyOrientationIdentity(t *testing.T) {
+	img := newStripImage()
+	if out := applyOrientation(img, 1); out != image.Image(img) {
+		t.Fatalf("orientation 1 should return the image unchanged")
+	}
+}
+
+func TestApplyOrientationFlipHorizontal(t *testing.T) {
+	img := newStripImage()
+	out := applyOrientation(img, 2)
+
+	if !colorsEqual(out.At(0, 0), color.NRGBA{G: 255, A: 255}) {
+		t.Errorf("flipHorizontal: (0,0) = %v, want green", out.At(0, 0))
+	}
+	if !colorsEqual(out.At(1, 0), color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("flipHorizontal: (1,0) = %v, want red", out.At(1, 0))
+	}
+}
+
+func TestApplyOrientationRotate90(t *testing.T) {
+	img := newStripImage()
+	out := applyOrientation(img, 6)
+
+	b := out.Bounds()
+	if b.Dx() != 1 || b.Dy() != 2 {
+		t.Fatalf("rotate90: bounds = %v, want 1x2", b)
+	}
+	if !colorsEqual(out.At(0, 0), color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("rotate90: (0,0) = %v, want red", out.At(0, 0))
+	}
+	if !colorsEqual(out.At(0, 1), color.NRGBA{G: 255, A: 255}) {
+		t.Errorf("rotate90: (0,1) = %v, want green", out.At(0, 1))
+	}
+}
+
+func TestApplyOrientationRotate180(t *testing.T) {
+	img := newStripImage()
+	out := applyOrientation(img, 3)
+
+	if !colorsEqual(out.At(0, 0), color.NRGBA{G: 255, A: 255}) {
+		t.Errorf("rotate180: (0,0) = %v, want green", out.At(0, 0))
+	}
+	if !colorsEqual(out.At(1, 0), color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("rotate180: (1,0) = %v, want red", out.At(1, 0))
+	}
+}
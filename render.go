@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path"
+
+	"github.com/fogleman/gg"
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// defaultFontPaths are tried, in order, whenever --font doesn't point at a
+// loadable .ttf file, so the tool keeps working on a bare system.
+var defaultFontPaths = []string{
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+	"/usr/share/fonts/truetype/liberation/LiberationSans-Regular.ttf",
+	"/Library/Fonts/Arial.ttf",
+}
+
+// annotateImageWith decodes the source JPEG, burns the annotation text onto
+// its bottom margin and re-encodes the result to destination, all in-process.
+// This replaces the former "convert" shell-out so the worker pool is
+// actually parallel and no longer depends on imagemagick being installed.
+func annotateImageWith(rootDirectory string, picture os.FileInfo, destPath string, annotation string, textInPointSize int, bottomMargin int) error {
+	srcPath := path.Join(rootDirectory, picture.Name())
+
+	img, err := decodeOriented(srcPath)
+	if err != nil {
+		return fmt.Errorf("can't decode image %v: %w", srcPath, err)
+	}
+
+	dc := gg.NewContextForImage(img)
+	if err := loadAnnotationFont(dc, textInPointSize); err != nil {
+		return fmt.Errorf("can't load font %v: %w", *font, err)
+	}
+
+	dc.SetRGB(0, 0, 0)
+	x := float64(dc.Width()) / 2
+	y := float64(dc.Height() - bottomMargin)
+	dc.DrawStringAnchored(normalizeUtf8Style(annotation), x, y, 0.5, 1)
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("can't create destination file %v: %w", destPath, err)
+	}
+	defer out.Close()
+
+	if err := jpeg.Encode(out, dc.Image(), &jpeg.Options{Quality: 92}); err != nil {
+		return fmt.Errorf("can't encode annotated image %v: %w", destPath, err)
+	}
+	return nil
+}
+
+// decodeOriented decodes a JPEG and rotates/flips it according to its EXIF
+// Orientation tag, so annotation is always drawn the right way up.
+func decodeOriented(srcPath string) (image.Image, error) {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, err := jpeg.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyOrientation(img, readOrientation(srcPath)), nil
+}
+
+// readOrientation returns the EXIF Orientation tag value for srcPath, or 1
+// (no transform needed) if it's missing or unreadable.
+func readOrientation(srcPath string) int {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return 1
+	}
+	defer file.Close()
+
+	x, err := exif.Decode(file)
+	if err != nil {
+		return 1
+	}
+
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+
+	value, err := tag.Int(0)
+	if err != nil {
+		return 1
+	}
+	return value
+}
+
+// loadAnnotationFont tries --font first, then falls back to a handful of
+// common system TrueType fonts so the tool still works without a config.
+func loadAnnotationFont(dc *gg.Context, textInPointSize int) error {
+	var lastErr error
+	for _, candidate := range append([]string{*font}, defaultFontPaths...) {
+		if err := dc.LoadFontFace(candidate, float64(textInPointSize)); err == nil {
+			return nil
+		} else {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileJob is one file discovered by the scan, paired with the location text
+// it should be annotated with: the --location flag value at the --src root,
+// or the name of its immediate parent directory when found in a subdirectory.
+type fileJob struct {
+	root     string
+	info     os.FileInfo
+	location string
+}
+
+// scanFiles walks src (recursing into subdirectories unless recursive is
+// false), skips destination entirely, and sends every file found into the
+// returned channel. The channel is closed once the walk completes.
+func scanFiles(src string, destination string, recursive bool, defaultLocation string) <-chan fileJob {
+	jobs := make(chan fileJob)
+
+	go func() {
+		defer close(jobs)
+
+		absDestination, err := filepath.Abs(destination)
+		if err != nil {
+			logger.Println("Can't resolve destination path : ", err)
+			return
+		}
+
+		err = filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				logger.Println("Can't read : ", p, err)
+				return nil
+			}
+
+			if absPath, absErr := filepath.Abs(p); absErr == nil && absPath == absDestination {
+				return filepath.SkipDir
+			}
+
+			if info.IsDir() {
+				if !recursive && p != src {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			relDir, err := filepath.Rel(src, filepath.Dir(p))
+			if err != nil {
+				relDir = "."
+			}
+
+			location := defaultLocation
+			if relDir != "." {
+				location = filepath.Base(relDir)
+			}
+
+			jobs <- fileJob{root: filepath.Dir(p), info: info, location: location}
+			return nil
+		})
+		if err != nil {
+			logger.Println("Error walking : ", src, err)
+		}
+	}()
+
+	return jobs
+}
+
+// scanSummary tallies per-file outcomes across the worker pool so a single
+// report can be printed once every job has been processed.
+type scanSummary struct {
+	mu              sync.Mutex
+	processed       int
+	skippedWrongExt int
+	skippedNoDate   int
+	failedAnnotate  int
+}
+
+func (s *scanSummary) record(outcome string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch outcome {
+	case "processed":
+		s.processed++
+	case "skipped-wrong-ext":
+		s.skippedWrongExt++
+	case "skipped-no-date":
+		s.skippedNoDate++
+	case "failed-annotate":
+		s.failedAnnotate++
+	}
+}
+
+func (s *scanSummary) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fmt.Sprintf("processed=%d skipped-wrong-ext=%d skipped-no-date=%d failed-annotate=%d",
+		s.processed, s.skippedWrongExt, s.skippedNoDate, s.failedAnnotate)
+}
+
+// runWorkerPool consumes jobs with a fixed pool of workers, each running
+// processFile, and blocks until every job has been handled.
+func runWorkerPool(jobs <-chan fileJob, workers int, destination string, a *arranger, summary *scanSummary) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				processFile(job, destination, a, summary)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// hasAllowedExt reports whether name's extension is one of the comma/plain
+// list configured via --ext.
+func hasAllowedExt(name string) bool {
+	localExt := filepath.Ext(name)
+	return localExt != "" && strings.Contains(*ext, localExt)
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestHasAllowedExt(t *testing.T) {
+	original := *ext
+	defer func() { *ext = original }()
+
+	*ext = ".jpg"
+	cases := []struct {
+		name string
+		want bool
+	}{
+		{"photo.jpg", true},
+		{"photo.png", false},
+		{"noextension", false},
+		{"photo.JPG", false},
+	}
+	for _, c := range cases {
+		if got := hasAllowedExt(c.name); got != c.want {
+			t.Errorf("hasAllowedExt(%q) with --ext=%q = %v, want %v", c.name, *ext, got, c.want)
+		}
+	}
+
+	*ext = ".jpg,.png"
+	if !hasAllowedExt("photo.png") {
+		t.Errorf("hasAllowedExt(\"photo.png\") with --ext=%q = false, want true", *ext)
+	}
+}